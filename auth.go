@@ -0,0 +1,211 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/codegangsta/cli"
+	log "github.com/sirupsen/logrus"
+)
+
+// authMethod selects how the CLI authenticates against the classic
+// management endpoint.
+type authMethod string
+
+const (
+	authMethodCert authMethod = "cert"
+	authMethodSP   authMethod = "sp"
+	authMethodCLI  authMethod = "cli"
+	authMethodMSI  authMethod = "msi"
+)
+
+// newManagementClient builds an ExtensionsClient for env using whichever
+// --auth-method was selected. It defaults to management certificates to
+// preserve backward compatibility with existing invocations.
+func newManagementClient(c *cli.Context, env azureEnvironment) ExtensionsClient {
+	mgtURL := c.String(flMgtURL.Name)
+	if mgtURL == "" {
+		mgtURL = env.ManagementURL
+	}
+	subscriptionID := checkFlag(c, flSubsID.Name)
+
+	switch authMethod(c.GlobalString(flAuthMethod.Name)) {
+	case authMethodSP:
+		return clientFromServicePrincipal(c, mgtURL, subscriptionID, env)
+	case authMethodCLI:
+		return clientFromAzureCLIToken(mgtURL, subscriptionID, env)
+	case authMethodMSI:
+		return clientFromManagedIdentity(mgtURL, subscriptionID, env)
+	case authMethodCert, "":
+		return clientFromCert(mgtURL, subscriptionID, checkFlag(c, flSubsCert.Name))
+	default:
+		log.Fatalf("Unknown --auth-method %q, must be one of cert, sp, cli, msi", c.GlobalString(flAuthMethod.Name))
+		return nil
+	}
+}
+
+// clientFromCert is the original management-certificate auth path.
+func clientFromCert(mgtURL, subscriptionID, certFile string) ExtensionsClient {
+	b, err := readCert(certFile)
+	if err != nil {
+		log.Fatalf("Cannot read certificate %s: %v", certFile, err)
+	}
+	cl, err := NewClient(mgtURL, subscriptionID, b)
+	if err != nil {
+		log.Fatalf("Cannot create client: %v", err)
+	}
+	return cl
+}
+
+// clientFromServicePrincipal authenticates with a service principal's
+// tenant ID, client ID and client secret against the environment's Active
+// Directory endpoint.
+func clientFromServicePrincipal(c *cli.Context, mgtURL, subscriptionID string, env azureEnvironment) ExtensionsClient {
+	tenantID := c.GlobalString(flTenantID.Name)
+	clientID := c.GlobalString(flClientID.Name)
+	clientSecret := c.GlobalString(flClientSecret.Name)
+	if tenantID == "" || clientID == "" || clientSecret == "" {
+		log.Fatalf("--auth-method=sp requires --%s, --%s and --%s (or their AZURE_TENANT_ID/AZURE_CLIENT_ID/AZURE_CLIENT_SECRET env vars)",
+			flTenantID.Name, flClientID.Name, flClientSecret.Name)
+	}
+	token, err := servicePrincipalToken(env, tenantID, clientID, clientSecret)
+	if err != nil {
+		log.Fatalf("Cannot acquire a service principal token: %v", err)
+	}
+	cl, err := NewClientFromToken(mgtURL, subscriptionID, token)
+	if err != nil {
+		log.Fatalf("Cannot create client: %v", err)
+	}
+	return cl
+}
+
+// clientFromAzureCLIToken reuses the access token cached by `az login` in
+// ~/.azure/accessTokens.json, matching it against the classic management
+// resource for env.
+func clientFromAzureCLIToken(mgtURL, subscriptionID string, env azureEnvironment) ExtensionsClient {
+	token, err := azureCLIToken(env)
+	if err != nil {
+		log.Fatalf("Cannot read Azure CLI token: %v", err)
+	}
+	cl, err := NewClientFromToken(mgtURL, subscriptionID, token)
+	if err != nil {
+		log.Fatalf("Cannot create client: %v", err)
+	}
+	return cl
+}
+
+// clientFromManagedIdentity acquires a token from the Azure Instance
+// Metadata Service, for use when the CLI runs on an Azure VM or in
+// Azure Automation with a managed identity assigned.
+func clientFromManagedIdentity(mgtURL, subscriptionID string, env azureEnvironment) ExtensionsClient {
+	token, err := managedIdentityToken(env)
+	if err != nil {
+		log.Fatalf("Cannot acquire a managed identity token: %v", err)
+	}
+	cl, err := NewClientFromToken(mgtURL, subscriptionID, token)
+	if err != nil {
+		log.Fatalf("Cannot create client: %v", err)
+	}
+	return cl
+}
+
+// servicePrincipalToken performs an OAuth2 client-credentials exchange
+// against env's Active Directory endpoint, returning a bearer token scoped
+// to the classic management resource.
+func servicePrincipalToken(env azureEnvironment, tenantID, clientID, clientSecret string) (string, error) {
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {clientID},
+		"client_secret": {clientSecret},
+		"resource":      {env.ManagementURL},
+	}
+	tokenURL := fmt.Sprintf("%s/%s/oauth2/token", strings.TrimRight(env.ActiveDirectoryEndpoint, "/"), tenantID)
+	resp, err := http.PostForm(tokenURL, form)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token request to %s failed with status %s", tokenURL, resp.Status)
+	}
+	var body struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("cannot parse token response: %v", err)
+	}
+	return body.AccessToken, nil
+}
+
+// azureCLIAccessToken is the shape of an entry in
+// ~/.azure/accessTokens.json as produced by `az login`.
+type azureCLIAccessToken struct {
+	Resource    string `json:"resource"`
+	AccessToken string `json:"accessToken"`
+	ExpiresOn   string `json:"expiresOn"`
+	TokenType   string `json:"tokenType"`
+}
+
+// azureCLIToken finds the cached Azure CLI token matching env's management
+// resource.
+func azureCLIToken(env azureEnvironment) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(home, ".azure", "accessTokens.json")
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("reading %s (have you run 'az login'?): %v", path, err)
+	}
+	var tokens []azureCLIAccessToken
+	if err := json.Unmarshal(b, &tokens); err != nil {
+		return "", fmt.Errorf("parsing %s: %v", path, err)
+	}
+	for _, t := range tokens {
+		if t.Resource == env.ManagementURL {
+			return t.AccessToken, nil
+		}
+	}
+	return "", fmt.Errorf("no cached token for resource %s in %s, run 'az login'", env.ManagementURL, path)
+}
+
+// managedIdentityToken asks the Azure Instance Metadata Service for a
+// token scoped to env's management resource.
+func managedIdentityToken(env azureEnvironment) (string, error) {
+	req, err := http.NewRequest("GET", "http://169.254.169.254/metadata/identity/oauth2/token", nil)
+	if err != nil {
+		return "", err
+	}
+	q := req.URL.Query()
+	q.Set("api-version", "2018-02-01")
+	q.Set("resource", env.ManagementURL)
+	req.URL.RawQuery = q.Encode()
+	req.Header.Set("Metadata", "true")
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		b, _ := ioutil.ReadAll(resp.Body)
+		return "", fmt.Errorf("instance metadata service returned %s: %s", resp.Status, bytes.TrimSpace(b))
+	}
+	var body struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("cannot parse token response: %v", err)
+	}
+	return body.AccessToken, nil
+}
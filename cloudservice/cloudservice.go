@@ -0,0 +1,82 @@
+// Package cloudservice models the classic Cloud Service (PaaS) extension
+// endpoints of the Azure Service Management API: the catalog of resource
+// extensions available to be added to a hosted service, and the extensions
+// already configured on one.
+package cloudservice
+
+import "encoding/xml"
+
+// ResourceExtension describes an extension available to be added to a
+// Cloud Service, as returned by GET services/resourceextensions.
+type ResourceExtension struct {
+	XMLName                     xml.Name `xml:"ResourceExtension"`
+	ProviderNameSpace           string   `xml:"ProviderNameSpace"`
+	Type                        string   `xml:"Type"`
+	Version                     string   `xml:"Version"`
+	Label                       string   `xml:"Label"`
+	Description                 string   `xml:"Description"`
+	PublicConfigurationSchema   string   `xml:"PublicConfigurationSchema"`
+	PrivateConfigurationSchema  string   `xml:"PrivateConfigurationSchema"`
+	SampleConfig                string   `xml:"SampleConfig"`
+	ReplicationCompleted        string   `xml:"ReplicationCompleted"`
+	Eula                        string   `xml:"Eula"`
+	PrivacyURI                  string   `xml:"PrivacyUri"`
+	HomepageURI                 string   `xml:"HomepageUri"`
+	IsJSONExtension             bool     `xml:"IsJsonExtension"`
+	IsInternalExtension         bool     `xml:"IsInternalExtension"`
+	DisallowMajorVersionUpgrade bool     `xml:"DisallowMajorVersionUpgrade"`
+	CompanyName                 string   `xml:"CompanyName"`
+	SupportedOS                 string   `xml:"SupportedOS"`
+}
+
+// ResourceExtensionList is the envelope returned by
+// GET services/resourceextensions.
+type ResourceExtensionList struct {
+	XMLName xml.Name            `xml:"ResourceExtensions"`
+	Items   []ResourceExtension `xml:"ResourceExtension"`
+}
+
+// Extension describes an extension configured on a Cloud Service, as
+// returned by GET services/hostedservices/{name}/extensions.
+type Extension struct {
+	XMLName              xml.Name `xml:"Extension"`
+	ProviderNameSpace    string   `xml:"ProviderNameSpace"`
+	Type                 string   `xml:"Type"`
+	ID                   string   `xml:"Id"`
+	Version              string   `xml:"Version"`
+	PublicConfiguration  string   `xml:"PublicConfiguration"`
+	PrivateConfiguration string   `xml:"PrivateConfiguration,omitempty"`
+	Thumbprint           string   `xml:"Thumbprint,omitempty"`
+	ThumbprintAlgorithm  string   `xml:"ThumbprintAlgorithm,omitempty"`
+}
+
+// ExtensionList is the envelope returned by
+// GET services/hostedservices/{name}/extensions.
+type ExtensionList struct {
+	XMLName xml.Name    `xml:"Extensions"`
+	Items   []Extension `xml:"Extension"`
+}
+
+// AddExtensionInput is the request body for
+// POST services/hostedservices/{name}/extensions.
+type AddExtensionInput struct {
+	XMLName              xml.Name `xml:"Extension"`
+	Xmlns                string   `xml:"xmlns,attr"`
+	ProviderNameSpace    string   `xml:"ProviderNameSpace"`
+	Type                 string   `xml:"Type"`
+	ID                   string   `xml:"Id"`
+	Version              string   `xml:"Version"`
+	PublicConfiguration  string   `xml:"PublicConfiguration"`
+	PrivateConfiguration string   `xml:"PrivateConfiguration,omitempty"`
+}
+
+// Marshal renders i as the XML payload expected by
+// POST services/hostedservices/{name}/extensions.
+func (i AddExtensionInput) Marshal() ([]byte, error) {
+	i.Xmlns = "http://schemas.microsoft.com/windowsazure"
+	b, err := xml.MarshalIndent(i, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), b...), nil
+}
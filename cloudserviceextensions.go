@@ -0,0 +1,161 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/DataDog/azure-extensions-cli/cloudservice"
+	"github.com/codegangsta/cli"
+	"github.com/olekukonko/tablewriter"
+	log "github.com/sirupsen/logrus"
+)
+
+func listAvailableResourceExtensions(c *cli.Context) {
+	cl := mkClient(c)
+	b, err := cl.SendAzureGetRequest("services/resourceextensions")
+	if err != nil {
+		log.Fatalf("Cannot list resource extensions: %v", err)
+	}
+	var list cloudservice.ResourceExtensionList
+	if err := xml.Unmarshal(b, &list); err != nil {
+		log.Fatalf("Cannot parse resource extensions: %v", err)
+	}
+	if c.Bool(flJSON.Name) {
+		printResourceExtensionsAsJSON(list.Items)
+		return
+	}
+	printResourceExtensionsAsTable(list.Items)
+}
+
+func listCloudServiceExtensions(c *cli.Context) {
+	cl := mkClient(c)
+	service := checkFlag(c, flServiceName.Name)
+	b, err := cl.SendAzureGetRequest(fmt.Sprintf("services/hostedservices/%s/extensions", service))
+	if err != nil {
+		log.Fatalf("Cannot list extensions for cloud service %s: %v", service, err)
+	}
+	var list cloudservice.ExtensionList
+	if err := xml.Unmarshal(b, &list); err != nil {
+		log.Fatalf("Cannot parse cloud service extensions: %v", err)
+	}
+	if c.Bool(flJSON.Name) {
+		printCloudServiceExtensionsAsJSON(list.Items)
+		return
+	}
+	printCloudServiceExtensionsAsTable(list.Items)
+}
+
+func addCloudServiceExtension(c *cli.Context) {
+	service := checkFlag(c, flServiceName.Name)
+	input := cloudservice.AddExtensionInput{
+		ProviderNameSpace: checkFlag(c, flNamespace.Name),
+		Type:              checkFlag(c, flName.Name),
+		ID:                fmt.Sprintf("%s-%s", checkFlag(c, flName.Name), checkFlag(c, flVersion.Name)),
+		Version:           checkFlag(c, flVersion.Name),
+	}
+	if p := c.String(flPublicConfig.Name); p != "" {
+		input.PublicConfiguration = readConfigFile(p)
+	}
+	if p := c.String(flPrivateConfig.Name); p != "" {
+		input.PrivateConfiguration = readConfigFile(p)
+	}
+	body, err := input.Marshal()
+	if err != nil {
+		log.Fatalf("Cannot build extension request: %v", err)
+	}
+
+	cl := mkClient(c)
+	op, err := cl.SendAzurePostRequest(fmt.Sprintf("services/hostedservices/%s/extensions", service), body)
+	if err != nil {
+		log.Fatalf("Cannot add extension to cloud service %s: %v", service, err)
+	}
+	lg := log.WithField("x-ms-operation-id", op)
+	if c.Bool(flAsync.Name) {
+		printOperationAsJSON(op)
+		return
+	}
+	lg.Info("AddExtension operation started.")
+	err = waitForOperation(cl, op, c.Duration(flTimeout.Name), c.Duration(flPollInterval.Name),
+		func(attempt int, elapsed time.Duration, status string) {
+			lg.WithFields(log.Fields{"attempt": attempt, "elapsed": elapsed, "status": status}).Debug("Polling operation status.")
+		})
+	if err != nil {
+		lg.Fatalf("AddExtension failed: %v", err)
+	}
+	lg.Info("AddExtension operation finished.")
+}
+
+func removeCloudServiceExtension(c *cli.Context) {
+	service := checkFlag(c, flServiceName.Name)
+	id := checkFlag(c, flExtensionID.Name)
+
+	cl := mkClient(c)
+	op, err := cl.SendAzureDeleteRequest(fmt.Sprintf("services/hostedservices/%s/extensions/%s", service, id))
+	if err != nil {
+		log.Fatalf("Cannot remove extension %s from cloud service %s: %v", id, service, err)
+	}
+	lg := log.WithField("x-ms-operation-id", op)
+	if c.Bool(flAsync.Name) {
+		printOperationAsJSON(op)
+		return
+	}
+	lg.Info("RemoveExtension operation started.")
+	err = waitForOperation(cl, op, c.Duration(flTimeout.Name), c.Duration(flPollInterval.Name),
+		func(attempt int, elapsed time.Duration, status string) {
+			lg.WithFields(log.Fields{"attempt": attempt, "elapsed": elapsed, "status": status}).Debug("Polling operation status.")
+		})
+	if err != nil {
+		lg.Fatalf("RemoveExtension failed: %v", err)
+	}
+	lg.Info("RemoveExtension operation finished.")
+}
+
+func readConfigFile(path string) string {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		log.Fatalf("Cannot read configuration file %s: %v", path, err)
+	}
+	return string(b)
+}
+
+func printResourceExtensionsAsJSON(items []cloudservice.ResourceExtension) {
+	b, err := json.MarshalIndent(items, "", "  ")
+	if err != nil {
+		log.Fatalf("failed to format as json: %+v", err)
+	}
+	fmt.Fprintf(os.Stdout, "%s", string(b))
+}
+
+func printResourceExtensionsAsTable(items []cloudservice.ResourceExtension) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Namespace", "Type", "Version", "Label"})
+	data := [][]string{}
+	for _, e := range items {
+		data = append(data, []string{e.ProviderNameSpace, e.Type, e.Version, e.Label})
+	}
+	table.AppendBulk(data)
+	table.Render()
+}
+
+func printCloudServiceExtensionsAsJSON(items []cloudservice.Extension) {
+	b, err := json.MarshalIndent(items, "", "  ")
+	if err != nil {
+		log.Fatalf("failed to format as json: %+v", err)
+	}
+	fmt.Fprintf(os.Stdout, "%s", string(b))
+}
+
+func printCloudServiceExtensionsAsTable(items []cloudservice.Extension) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Id", "Namespace", "Type", "Version"})
+	data := [][]string{}
+	for _, e := range items {
+		data = append(data, []string{e.ID, e.ProviderNameSpace, e.Type, e.Version})
+	}
+	table.AppendBulk(data)
+	table.Render()
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/Azure/azure-sdk-for-go/management"
+)
+
+// azureEnvironment bundles the service endpoints that differ between
+// Azure's public cloud and its sovereign clouds.
+//
+// StorageBaseURL is intentionally not modeled here: no command in this CLI
+// resolves a storage base URL through an azureEnvironment today (the only
+// consumer of --storage-base-url, new-extension-manifest, reads the raw
+// flag value directly). Add it back once something actually threads it
+// through.
+type azureEnvironment struct {
+	Name                    string
+	ManagementURL           string
+	ActiveDirectoryEndpoint string
+}
+
+// azureEnvironments is the set of clouds resolvable via --environment /
+// AZURE_ENVIRONMENT.
+var azureEnvironments = map[string]azureEnvironment{
+	"AzurePublicCloud": {
+		Name:                    "AzurePublicCloud",
+		ManagementURL:           management.DefaultAzureManagementURL,
+		ActiveDirectoryEndpoint: "https://login.microsoftonline.com",
+	},
+	"AzureUSGovernmentCloud": {
+		Name:                    "AzureUSGovernmentCloud",
+		ManagementURL:           "https://management.core.usgovcloudapi.net",
+		ActiveDirectoryEndpoint: "https://login.microsoftonline.us",
+	},
+	"AzureChinaCloud": {
+		Name:                    "AzureChinaCloud",
+		ManagementURL:           "https://management.core.chinacloudapi.cn",
+		ActiveDirectoryEndpoint: "https://login.chinacloudapi.cn",
+	},
+	"AzureGermanCloud": {
+		Name:                    "AzureGermanCloud",
+		ManagementURL:           "https://management.core.cloudapi.de",
+		ActiveDirectoryEndpoint: "https://login.microsoftonline.de",
+	},
+}
+
+// environmentFromName resolves name to its azureEnvironment, defaulting to
+// AzurePublicCloud when name is empty.
+func environmentFromName(name string) (azureEnvironment, error) {
+	if name == "" {
+		name = "AzurePublicCloud"
+	}
+	env, ok := azureEnvironments[name]
+	if !ok {
+		return azureEnvironment{}, fmt.Errorf("unknown --environment %q", name)
+	}
+	return env, nil
+}
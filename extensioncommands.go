@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"strings"
+
+	"github.com/codegangsta/cli"
+	log "github.com/sirupsen/logrus"
+)
+
+// extensionManifestMeta is the subset of a 'new-extension-manifest' XML
+// output that promote/promote-all-regions need to address the extension
+// version being promoted.
+type extensionManifestMeta struct {
+	XMLName           xml.Name `xml:"ExtensionImage"`
+	ProviderNameSpace string   `xml:"ProviderNameSpace"`
+	Type              string   `xml:"Type"`
+	Version           string   `xml:"Version"`
+}
+
+// readManifest reads the file at --manifest, failing fast if it is missing.
+func readManifest(c *cli.Context) []byte {
+	path := checkFlag(c, flManifest.Name)
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		log.Fatalf("Cannot read --manifest %s: %v", path, err)
+	}
+	return b
+}
+
+// parseManifestMeta extracts the extension coordinates from manifest.
+func parseManifestMeta(manifest []byte) extensionManifestMeta {
+	var m extensionManifestMeta
+	if err := xml.Unmarshal(manifest, &m); err != nil {
+		log.Fatalf("Cannot parse --manifest: %v", err)
+	}
+	return m
+}
+
+// createExtension implements new-extension: registers a new extension type
+// from a manifest produced by new-extension-manifest.
+func createExtension(c *cli.Context) {
+	manifest := readManifest(c)
+	cl := mkClient(c)
+	op, err := cl.SendAzurePostRequest("services/resourceextensions", manifest)
+	if err != nil {
+		log.Fatalf("CreateExtension failed: %v", err)
+	}
+	finishExtensionOperation(c, cl, "CreateExtension", op)
+}
+
+// updateExtension implements new-extension-version: publishes a new,
+// internal-only version of an already-registered extension.
+func updateExtension(c *cli.Context) {
+	manifest := readManifest(c)
+	cl := mkClient(c)
+	op, err := cl.UpdateExtension(manifest)
+	if err != nil {
+		log.Fatalf("UpdateExtension failed: %v", err)
+	}
+	finishExtensionOperation(c, cl, "UpdateExtension", op)
+}
+
+// promoteToRegions implements promote: rolls an internal extension version
+// out to PROD in the regions selected with --region.
+func promoteToRegions(c *cli.Context) {
+	regions := c.StringSlice(flRegion.Name)
+	if len(regions) == 0 {
+		log.Fatalf("--region must be provided at least once for promote")
+	}
+	meta := parseManifestMeta(readManifest(c))
+	cl := mkClient(c)
+	path := fmt.Sprintf("services/resourceextensions/%s/%s/%s/replicate?regions=%s",
+		meta.ProviderNameSpace, meta.Type, meta.Version, url.QueryEscape(strings.Join(regions, ";")))
+	op, err := cl.SendAzurePostRequest(path, nil)
+	if err != nil {
+		log.Fatalf("Promote failed: %v", err)
+	}
+	finishExtensionOperation(c, cl, "Promote", op)
+}
+
+// promoteToAllRegions implements promote-all-regions: rolls an internal
+// extension version out to PROD in every region.
+func promoteToAllRegions(c *cli.Context) {
+	meta := parseManifestMeta(readManifest(c))
+	cl := mkClient(c)
+	path := fmt.Sprintf("services/resourceextensions/%s/%s/%s/replicateall",
+		meta.ProviderNameSpace, meta.Type, meta.Version)
+	op, err := cl.SendAzurePostRequest(path, nil)
+	if err != nil {
+		log.Fatalf("PromoteAllRegions failed: %v", err)
+	}
+	finishExtensionOperation(c, cl, "PromoteAllRegions", op)
+}
+
+// deleteVersion implements delete-version: permanently removes an
+// unpublished extension version.
+func deleteVersion(c *cli.Context) {
+	ns, name, version := checkFlag(c, flNamespace.Name), checkFlag(c, flName.Name), checkFlag(c, flVersion.Name)
+	cl := mkClient(c)
+	op, err := cl.SendAzureDeleteRequest(fmt.Sprintf("services/resourceextensions/%s/%s/%s", ns, name, version))
+	if err != nil {
+		log.Fatalf("DeleteExtension failed: %v", err)
+	}
+	finishExtensionOperation(c, cl, "DeleteExtension", op)
+}
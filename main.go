@@ -5,8 +5,8 @@ import (
 	"encoding/pem"
 	"io/ioutil"
 	"os"
+	"time"
 
-	"github.com/Azure/azure-sdk-for-go/management"
 	"github.com/Azure/azure-sdk-for-go/storage"
 	"github.com/codegangsta/cli"
 	"golang.org/x/crypto/pkcs12"
@@ -32,14 +32,35 @@ var (
 	flManifest = cli.StringFlag{
 		Name:  "manifest",
 		Usage: "Path of extension manifest file (XML output of 'new-extension-manifest')"}
+	flEnvironment = cli.StringFlag{
+		Name:   "environment",
+		Usage:  "Azure cloud environment to target (AzurePublicCloud, AzureUSGovernmentCloud, AzureChinaCloud, AzureGermanCloud)",
+		Value:  "AzurePublicCloud",
+		EnvVar: "AZURE_ENVIRONMENT"}
+	flAuthMethod = cli.StringFlag{
+		Name:   "auth-method",
+		Usage:  "Authentication method to use: cert (management certificate), sp (service principal), cli (az login token), msi (managed identity)",
+		Value:  "cert",
+		EnvVar: "AZURE_AUTH_METHOD"}
+	flTenantID = cli.StringFlag{
+		Name:   "tenant-id",
+		Usage:  "Azure AD tenant ID, required for --auth-method=sp",
+		EnvVar: "AZURE_TENANT_ID"}
+	flClientID = cli.StringFlag{
+		Name:   "client-id",
+		Usage:  "Service principal client (application) ID, required for --auth-method=sp",
+		EnvVar: "AZURE_CLIENT_ID"}
+	flClientSecret = cli.StringFlag{
+		Name:   "client-secret",
+		Usage:  "Service principal client secret, required for --auth-method=sp",
+		EnvVar: "AZURE_CLIENT_SECRET"}
 	flMgtURL = cli.StringFlag{
 		Name:   "management-url",
-		Usage:  "Azure Management URL for a non-public Azure cloud",
-		Value:  management.DefaultAzureManagementURL,
+		Usage:  "Overrides the Azure Management URL resolved from --environment",
 		EnvVar: "MANAGEMENT_URL"}
 	flStorageRealm = cli.StringFlag{
 		Name:   "storage-base-url",
-		Usage:  "Azure Storage base URL",
+		Usage:  "Azure Storage base URL; defaults to the public cloud's and is not resolved from --environment",
 		Value:  storage.DefaultBaseURL,
 		EnvVar: "STORAGE_BASE_URL"}
 	flSubsID = cli.StringFlag{
@@ -75,6 +96,36 @@ var (
 	flIsXMLExtension = cli.BoolFlag{
 		Name:  "is-xml-extension",
 		Usage: "Set if this is an XML extension, i.e. PaaS"}
+	flServiceName = cli.StringFlag{
+		Name:  "service",
+		Usage: "Name of the Cloud Service (hosted service)"}
+	flExtensionID = cli.StringFlag{
+		Name:  "extension-id",
+		Usage: "Id of the extension instance configured on a Cloud Service"}
+	flPublicConfig = cli.StringFlag{
+		Name:  "public-config",
+		Usage: "Path of a file containing the public configuration for the extension"}
+	flPrivateConfig = cli.StringFlag{
+		Name:  "private-config",
+		Usage: "Path of a file containing the private configuration for the extension"}
+	flAsync = cli.BoolFlag{
+		Name:  "async",
+		Usage: "Do not wait for the operation to finish; print the operation id and exit immediately"}
+	flTimeout = cli.DurationFlag{
+		Name:  "timeout",
+		Usage: "Maximum time to wait for an operation to finish",
+		Value: time.Hour}
+	flPollInterval = cli.DurationFlag{
+		Name:  "poll-interval",
+		Usage: "Time to wait between operation status polls",
+		Value: 10 * time.Second}
+	flOperationID = cli.StringFlag{
+		Name:  "operation-id",
+		Usage: "x-ms-operation-id of a previously started operation to wait on"}
+	flTargetStatus = cli.StringFlag{
+		Name:  "target-status",
+		Usage: "Replication status every selected region must reach",
+		Value: "Ready"}
 )
 
 func main() {
@@ -83,6 +134,7 @@ func main() {
 	app.Version = GitSummary
 	app.Usage = "This tool is designed for Microsoft internal extension publishers to release, update and manage Virtual Machine extensions."
 	app.Authors = []cli.Author{{Name: "Ahmet Alp Balkan", Email: "ahmetb at microsoft döt com"}}
+	app.Flags = []cli.Flag{flEnvironment, flAuthMethod, flTenantID, flClientID, flClientSecret}
 	app.Commands = []cli.Command{
 		{Name: "new-extension-manifest",
 			Usage:  "Creates an XML file used to publish or update extension.",
@@ -114,19 +166,19 @@ func main() {
 			}},
 		{Name: "new-extension",
 			Usage:  "Creates a new type of extension, not for releasing new versions.",
-			Flags:  []cli.Flag{flMgtURL, flSubsID, flSubsCert, flManifest},
+			Flags:  []cli.Flag{flMgtURL, flSubsID, flSubsCert, flManifest, flAsync, flTimeout, flPollInterval},
 			Action: createExtension},
 		{Name: "new-extension-version",
 			Usage:  "Publishes a new type of extension internally.",
-			Flags:  []cli.Flag{flMgtURL, flSubsID, flSubsCert, flManifest},
+			Flags:  []cli.Flag{flMgtURL, flSubsID, flSubsCert, flManifest, flAsync, flTimeout, flPollInterval},
 			Action: updateExtension},
 		{Name: "promote",
 			Usage:  "Promote published internal extension to PROD in one or more locations.",
-			Flags:  []cli.Flag{flMgtURL, flSubsID, flSubsCert, flManifest, flRegion},
+			Flags:  []cli.Flag{flMgtURL, flSubsID, flSubsCert, flManifest, flRegion, flAsync, flTimeout, flPollInterval},
 			Action: promoteToRegions},
 		{Name: "promote-all-regions",
 			Usage:  "Promote published extension to all Locations.",
-			Flags:  []cli.Flag{flMgtURL, flSubsID, flSubsCert, flManifest},
+			Flags:  []cli.Flag{flMgtURL, flSubsID, flSubsCert, flManifest, flAsync, flTimeout, flPollInterval},
 			Action: promoteToAllRegions},
 		{Name: "list-versions",
 			Usage:  "Lists all published extension versions for subscription",
@@ -138,26 +190,46 @@ func main() {
 			Action: replicationStatus},
 		{Name: "unpublish-version",
 			Usage:  "Marks the specified version of the extension internal. Does not delete.",
-			Flags:  []cli.Flag{flMgtURL, flSubsID, flSubsCert, flNamespace, flName, flVersion, flIsXMLExtension},
+			Flags:  []cli.Flag{flMgtURL, flSubsID, flSubsCert, flNamespace, flName, flVersion, flIsXMLExtension, flAsync, flTimeout, flPollInterval},
 			Action: unpublishVersion},
 		{Name: "delete-version",
 			Usage:  "Deletes the extension version. It should be unpublished first.",
-			Flags:  []cli.Flag{flMgtURL, flSubsID, flSubsCert, flNamespace, flName, flVersion},
+			Flags:  []cli.Flag{flMgtURL, flSubsID, flSubsCert, flNamespace, flName, flVersion, flAsync, flTimeout, flPollInterval},
 			Action: deleteVersion},
+		{Name: "list-available-resource-extensions",
+			Usage:  "Lists extensions available to be added to Cloud Services (PaaS).",
+			Flags:  []cli.Flag{flMgtURL, flSubsID, flSubsCert, flJSON},
+			Action: listAvailableResourceExtensions},
+		{Name: "list-cloud-service-extensions",
+			Usage:  "Lists extensions configured on a Cloud Service (PaaS).",
+			Flags:  []cli.Flag{flMgtURL, flSubsID, flSubsCert, flServiceName, flJSON},
+			Action: listCloudServiceExtensions},
+		{Name: "add-cloud-service-extension",
+			Usage:  "Adds an extension to a Cloud Service (PaaS).",
+			Flags:  []cli.Flag{flMgtURL, flSubsID, flSubsCert, flServiceName, flNamespace, flName, flVersion, flPublicConfig, flPrivateConfig, flAsync, flTimeout, flPollInterval},
+			Action: addCloudServiceExtension},
+		{Name: "remove-cloud-service-extension",
+			Usage:  "Removes an extension from a Cloud Service (PaaS).",
+			Flags:  []cli.Flag{flMgtURL, flSubsID, flSubsCert, flServiceName, flExtensionID, flAsync, flTimeout, flPollInterval},
+			Action: removeCloudServiceExtension},
+		{Name: "wait-operation",
+			Usage:  "Polls a previously started operation until it reaches a terminal status.",
+			Flags:  []cli.Flag{flMgtURL, flSubsID, flSubsCert, flOperationID, flTimeout, flPollInterval, flJSON},
+			Action: waitOperation},
+		{Name: "wait-replication",
+			Usage:  "Blocks until all (or a subset of) regions reach a target replication status.",
+			Flags:  []cli.Flag{flMgtURL, flSubsID, flSubsCert, flNamespace, flName, flVersion, flRegion, flTargetStatus, flTimeout, flPollInterval, flJSON},
+			Action: waitReplication},
 	}
 	app.RunAndExitOnError()
 }
 
-func mkClient(mgtURL, subscriptionID, certFile string) ExtensionsClient {
-	b, err := readCert(certFile)
-	if err != nil {
-		log.Fatalf("Cannot read certificate %s: %v", certFile, err)
-	}
-	cl, err := NewClient(mgtURL, subscriptionID, b)
+func mkClient(c *cli.Context) ExtensionsClient {
+	env, err := environmentFromName(c.GlobalString(flEnvironment.Name))
 	if err != nil {
-		log.Fatalf("Cannot create client: %v", err)
+		log.Fatalf("Cannot resolve --environment: %v", err)
 	}
-	return cl
+	return newManagementClient(c, env)
 }
 
 func readCert(certFile string) ([]byte, error) {
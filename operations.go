@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/management"
+	"github.com/codegangsta/cli"
+	log "github.com/sirupsen/logrus"
+)
+
+// operationResult is the JSON shape printed for --async and emitted by
+// wait-operation for each poll.
+type operationResult struct {
+	OperationID string `json:"operation_id"`
+	Attempt     int    `json:"attempt,omitempty"`
+	Elapsed     string `json:"elapsed,omitempty"`
+	Status      string `json:"status,omitempty"`
+}
+
+// printOperationAsJSON prints op's id as JSON, for --async invocations that
+// don't wait for the operation to finish.
+func printOperationAsJSON(op string) {
+	b, err := json.MarshalIndent(operationResult{OperationID: op}, "", "  ")
+	if err != nil {
+		log.Fatalf("failed to format as json: %+v", err)
+	}
+	fmt.Fprintf(os.Stdout, "%s\n", string(b))
+}
+
+// waitOperation implements the wait-operation command: it polls an
+// already-started operation id until it finishes, streaming progress.
+func waitOperation(c *cli.Context) {
+	cl := mkClient(c)
+	op := checkFlag(c, flOperationID.Name)
+	asJSON := c.Bool(flJSON.Name)
+	lg := log.WithField("x-ms-operation-id", op)
+
+	err := waitForOperation(cl, op, c.Duration(flTimeout.Name), c.Duration(flPollInterval.Name),
+		func(attempt int, elapsed time.Duration, status string) {
+			if asJSON {
+				b, _ := json.Marshal(operationResult{OperationID: op, Attempt: attempt, Elapsed: elapsed.String(), Status: status})
+				fmt.Fprintf(os.Stdout, "%s\n", string(b))
+				return
+			}
+			lg.WithFields(log.Fields{"attempt": attempt, "elapsed": elapsed, "status": status}).Info("Polling operation status.")
+		})
+	if err != nil {
+		lg.Fatalf("Operation failed: %v", err)
+	}
+	lg.Info("Operation finished.")
+}
+
+// finishExtensionOperation applies the common --async/--timeout/--poll-interval
+// handling shared by the extension lifecycle commands (new-extension,
+// new-extension-version, promote, promote-all-regions, delete-version):
+// print and return immediately under --async, otherwise block until opName
+// reaches a terminal status.
+func finishExtensionOperation(c *cli.Context, cl ExtensionsClient, opName, op string) {
+	lg := log.WithField("x-ms-operation-id", op)
+	if c.Bool(flAsync.Name) {
+		printOperationAsJSON(op)
+		return
+	}
+	lg.Infof("%s operation started.", opName)
+	err := waitForOperation(cl, op, c.Duration(flTimeout.Name), c.Duration(flPollInterval.Name),
+		func(attempt int, elapsed time.Duration, status string) {
+			lg.WithFields(log.Fields{"attempt": attempt, "elapsed": elapsed, "status": status}).Debug("Polling operation status.")
+		})
+	if err != nil {
+		lg.Fatalf("%s failed: %v", opName, err)
+	}
+	lg.Infof("%s operation finished.", opName)
+}
+
+// waitForOperation polls cl for op's status every pollInterval, invoking
+// onProgress after each attempt, until op reaches a terminal status or
+// timeout elapses.
+func waitForOperation(cl ExtensionsClient, op string, timeout, pollInterval time.Duration, onProgress func(attempt int, elapsed time.Duration, status string)) error {
+	start := time.Now()
+	for attempt := 1; ; attempt++ {
+		status, err := cl.GetOperationStatus(op)
+		if err != nil {
+			return err
+		}
+		if onProgress != nil {
+			onProgress(attempt, time.Since(start), string(status.Status))
+		}
+		switch status.Status {
+		case management.OperationStatusSucceeded:
+			return nil
+		case management.OperationStatusFailed:
+			msg := "unknown error"
+			if status.Error != nil {
+				msg = status.Error.Message
+			}
+			return fmt.Errorf("operation %s failed: %s", op, msg)
+		}
+		if time.Since(start) > timeout {
+			return fmt.Errorf("timed out waiting for operation %s after %s", op, timeout)
+		}
+		time.Sleep(pollInterval)
+	}
+}
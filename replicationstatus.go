@@ -11,7 +11,7 @@ import (
 )
 
 func replicationStatus(c *cli.Context) {
-	cl := mkClient(checkFlag(c, flMgtURL.Name), checkFlag(c, flSubsID.Name), checkFlag(c, flSubsCert.Name))
+	cl := mkClient(c)
 	ns, name, version := checkFlag(c, flNamespace.Name), checkFlag(c, flName.Name), checkFlag(c, flVersion.Name)
 	json := c.Bool(flJSON.Name)
 	log.Debug("Requesting replication status.")
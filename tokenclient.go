@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/management"
+)
+
+// managementAPIVersion is the classic Service Management API version this
+// CLI speaks, matching what management.Client sends for certificate auth.
+const managementAPIVersion = "2015-04-01"
+
+// NewClientFromToken builds an ExtensionsClient authenticated with an
+// OAuth2 bearer token against the classic management endpoint, rather than
+// a management certificate. It backs --auth-method={sp,cli,msi}.
+func NewClientFromToken(mgtURL, subscriptionID, token string) (ExtensionsClient, error) {
+	if token == "" {
+		return nil, fmt.Errorf("empty bearer token")
+	}
+	return &tokenManagementClient{
+		httpClient:     &http.Client{Transport: &bearerTokenTransport{token: token}},
+		managementURL:  strings.TrimRight(mgtURL, "/"),
+		subscriptionID: subscriptionID,
+	}, nil
+}
+
+// bearerTokenTransport injects an OAuth2 bearer token into every request,
+// the token-auth equivalent of the TLS client-certificate transport
+// management.Client uses for --auth-method=cert.
+type bearerTokenTransport struct {
+	token string
+}
+
+func (t *bearerTokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	r := req.Clone(req.Context())
+	r.Header.Set("Authorization", "Bearer "+t.token)
+	r.Header.Set("x-ms-version", managementAPIVersion)
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+// tokenManagementClient implements ExtensionsClient by calling the classic
+// Azure Service Management REST API directly over httpClient, mirroring
+// the request plumbing management.Client provides for certificate auth.
+type tokenManagementClient struct {
+	httpClient     *http.Client
+	managementURL  string
+	subscriptionID string
+}
+
+func (c *tokenManagementClient) url(path string) string {
+	return fmt.Sprintf("%s/%s/%s", c.managementURL, c.subscriptionID, path)
+}
+
+func (c *tokenManagementClient) SendAzureGetRequest(path string) ([]byte, error) {
+	resp, err := c.httpClient.Get(c.url(path))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("GET %s: %s: %s", path, resp.Status, bytes.TrimSpace(b))
+	}
+	return b, nil
+}
+
+func (c *tokenManagementClient) sendMutatingRequest(method, path string, body []byte) (string, error) {
+	req, err := http.NewRequest(method, c.url(path), bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/xml")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	b, _ := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode >= 300 {
+		return "", fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, bytes.TrimSpace(b))
+	}
+	return resp.Header.Get("x-ms-request-id"), nil
+}
+
+func (c *tokenManagementClient) SendAzurePostRequest(path string, data []byte) (string, error) {
+	return c.sendMutatingRequest(http.MethodPost, path, data)
+}
+
+func (c *tokenManagementClient) SendAzureDeleteRequest(path string) (string, error) {
+	return c.sendMutatingRequest(http.MethodDelete, path, nil)
+}
+
+func (c *tokenManagementClient) UpdateExtension(body []byte) (string, error) {
+	return c.sendMutatingRequest(http.MethodPut, "services/extensions", body)
+}
+
+func (c *tokenManagementClient) GetOperationStatus(operationID string) (management.GetOperationStatusResponse, error) {
+	b, err := c.SendAzureGetRequest(fmt.Sprintf("operations/%s", operationID))
+	if err != nil {
+		return management.GetOperationStatusResponse{}, err
+	}
+	var status management.GetOperationStatusResponse
+	if err := xml.Unmarshal(b, &status); err != nil {
+		return management.GetOperationStatusResponse{}, fmt.Errorf("cannot parse operation status: %v", err)
+	}
+	return status, nil
+}
+
+func (c *tokenManagementClient) WaitForOperation(operationID string) error {
+	return waitForOperation(c, operationID, time.Hour, 10*time.Second, nil)
+}
+
+func (c *tokenManagementClient) GetReplicationStatus(namespace, name, version string) (ReplicationStatusResponse, error) {
+	path := fmt.Sprintf("services/publisher/registeredresources/%s/%s/%s/replicationstatus", namespace, name, version)
+	b, err := c.SendAzureGetRequest(path)
+	if err != nil {
+		return ReplicationStatusResponse{}, err
+	}
+	var rs ReplicationStatusResponse
+	if err := xml.Unmarshal(b, &rs); err != nil {
+		return ReplicationStatusResponse{}, fmt.Errorf("cannot parse replication status: %v", err)
+	}
+	return rs, nil
+}
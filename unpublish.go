@@ -3,6 +3,7 @@ package main
 import (
 	"bytes"
 	"text/template"
+	"time"
 
 	"github.com/codegangsta/cli"
 	log "github.com/sirupsen/logrus"
@@ -43,14 +44,22 @@ func unpublishVersion(c *cli.Context) {
 		log.Fatalf("template execute error: %v", err)
 	}
 
-	cl := mkClient(checkFlag(c, flMgtURL.Name), checkFlag(c, flSubsID.Name), checkFlag(c, flSubsCert.Name))
+	cl := mkClient(c)
 	op, err := cl.UpdateExtension(b.Bytes())
 	if err != nil {
 		log.Fatalf("UpdateExtension failed: %v", err)
 	}
 	lg := log.WithField("x-ms-operation-id", op)
+	if c.Bool(flAsync.Name) {
+		printOperationAsJSON(op)
+		return
+	}
 	lg.Info("UpdateExtension operation started.")
-	if err := cl.WaitForOperation(op); err != nil {
+	err = waitForOperation(cl, op, c.Duration(flTimeout.Name), c.Duration(flPollInterval.Name),
+		func(attempt int, elapsed time.Duration, status string) {
+			lg.WithFields(log.Fields{"attempt": attempt, "elapsed": elapsed, "status": status}).Debug("Polling operation status.")
+		})
+	if err != nil {
 		lg.Fatalf("UpdateExtension failed: %v", err)
 	}
 	lg.Info("UpdateExtension operation finished.")
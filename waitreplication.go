@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/codegangsta/cli"
+	log "github.com/sirupsen/logrus"
+)
+
+// terminalReplicationFailureStatuses are statuses a region can never
+// recover from, so wait-replication gives up on them immediately instead
+// of waiting out the full timeout.
+var terminalReplicationFailureStatuses = map[string]bool{
+	"Failed": true,
+}
+
+func waitReplication(c *cli.Context) {
+	cl := mkClient(c)
+	ns, name, version := checkFlag(c, flNamespace.Name), checkFlag(c, flName.Name), checkFlag(c, flVersion.Name)
+	targetStatus := c.String(flTargetStatus.Name)
+	regions := c.StringSlice(flRegion.Name)
+	asJSON := c.Bool(flJSON.Name)
+	timeout := c.Duration(flTimeout.Name)
+	pollInterval := c.Duration(flPollInterval.Name)
+
+	selected := func(location string) bool {
+		if len(regions) == 0 {
+			return true
+		}
+		for _, r := range regions {
+			if r == location {
+				return true
+			}
+		}
+		return false
+	}
+
+	last := map[string]string{}
+	start := time.Now()
+	for attempt := 1; ; attempt++ {
+		rs, err := cl.GetReplicationStatus(ns, name, version)
+		if err != nil {
+			log.Fatalf("Cannot fetch replication status: %v", err)
+		}
+
+		available := map[string]bool{}
+		var pending, failed []string
+		for _, s := range rs.Statuses {
+			available[s.Location] = true
+			if !selected(s.Location) {
+				continue
+			}
+			if last[s.Location] != s.Status {
+				emitReplicationDelta(asJSON, attempt, time.Since(start), s.Location, s.Status)
+				last[s.Location] = s.Status
+			}
+			switch {
+			case s.Status == targetStatus:
+			case terminalReplicationFailureStatuses[s.Status]:
+				failed = append(failed, s.Location)
+			default:
+				pending = append(pending, s.Location)
+			}
+		}
+
+		if unknown := unselectedRegions(regions, available); len(unknown) > 0 {
+			log.Fatalf("Requested --region value(s) not present in the replication status response (check for typos): %v", unknown)
+		}
+		if len(failed) > 0 {
+			log.Fatalf("Regions reached a terminal status before reaching %q: %v", targetStatus, failed)
+		}
+		if len(pending) == 0 {
+			log.Infof("All selected regions reached %q.", targetStatus)
+			return
+		}
+		if time.Since(start) > timeout {
+			log.Fatalf("Timed out after %s waiting for regions to reach %q: %v", timeout, targetStatus, pending)
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+// unselectedRegions returns the entries of requested that never appeared
+// as a Location in the replication status response, which almost always
+// means a typo'd --region value rather than a region still replicating.
+func unselectedRegions(requested []string, available map[string]bool) []string {
+	var missing []string
+	for _, r := range requested {
+		if !available[r] {
+			missing = append(missing, r)
+		}
+	}
+	return missing
+}
+
+func emitReplicationDelta(asJSON bool, attempt int, elapsed time.Duration, location, status string) {
+	if asJSON {
+		b, _ := json.Marshal(struct {
+			Attempt  int    `json:"attempt"`
+			Elapsed  string `json:"elapsed"`
+			Location string `json:"location"`
+			Status   string `json:"status"`
+		}{attempt, elapsed.String(), location, status})
+		fmt.Fprintf(os.Stdout, "%s\n", string(b))
+		return
+	}
+	log.WithFields(log.Fields{"attempt": attempt, "elapsed": elapsed, "location": location, "status": status}).Info("Region replication status changed.")
+}